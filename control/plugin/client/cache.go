@@ -0,0 +1,230 @@
+package client
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/intelsdi-x/pulse/control/plugin"
+)
+
+// defaultCacheTTL is used for ordinary cacheable methods (e.g.
+// Collector.CollectMetrics), where a cached response can go stale in well
+// under a second.
+const defaultCacheTTL = 500 * time.Millisecond
+
+// staticCacheTTL is used for methods whose result can't change for the
+// lifetime of a plugin (Collector.GetMetricTypes, SessionState.GetConfigPolicy):
+// a plugin doesn't grow new metrics or change its config policy mid-run, so
+// there's no reason to let these expire on the same clock as CollectMetrics.
+const staticCacheTTL = 1 * time.Hour
+
+// staticCacheMethods lists the methods cacheTTLFor treats as immutable for
+// the lifetime of a plugin rather than merely idempotent.
+var staticCacheMethods = map[string]bool{
+	"Collector.GetMetricTypes":     true,
+	"SessionState.GetConfigPolicy": true,
+}
+
+// metricNamespaceKey turns a metric namespace into a stable map key for
+// metricCacheTTLs, independent of the concrete namespace type a given
+// version of core.Metric.Namespace() returns.
+func metricNamespaceKey(ns interface{}) string {
+	return fmt.Sprintf("%v", ns)
+}
+
+// WithMetricCacheTTL overrides the cache TTL used for Collector.CollectMetrics
+// responses for a single metric namespace, e.g. to honor a per-metric rule
+// pulled from the plugin's ConfigPolicy before the client is ever used to
+// collect. Metrics without an override use the client's default cacheTTL
+// (see WithCacheTTL). Last call for a given namespace wins.
+func WithMetricCacheTTL(namespace []string, ttl time.Duration) ClientOpt {
+	key := metricNamespaceKey(namespace)
+	return func(h *httpJSONRPCClient) {
+		if h.metricCacheTTLs == nil {
+			h.metricCacheTTLs = make(map[string]time.Duration)
+		}
+		h.metricCacheTTLs[key] = ttl
+	}
+}
+
+// cacheTTLFor returns how long a cached response for method+params should
+// live: staticCacheTTL for the handful of methods whose result never
+// changes once a plugin has started; for Collector.CollectMetrics, the
+// per-metric override from WithMetricCacheTTL if params carries a
+// plugin.PluginMetricType with one; h.cacheTTL (defaultCacheTTL, unless
+// overridden via WithCacheTTL) otherwise.
+func (h *httpJSONRPCClient) cacheTTLFor(method string, params interface{}) time.Duration {
+	if staticCacheMethods[method] {
+		return staticCacheTTL
+	}
+	if pmt, ok := params.(plugin.PluginMetricType); ok {
+		if ttl, ok := h.metricCacheTTLs[metricNamespaceKey(pmt.Namespace())]; ok {
+			return ttl
+		}
+	}
+	return h.cacheTTL
+}
+
+// defaultCacheCapacity bounds the default in-memory cache so a chatty
+// high-cardinality collector can't grow it without bound.
+const defaultCacheCapacity = 4096
+
+// ResponseCache short-circuits idempotent RPC calls by caching the raw,
+// already-encoded response body keyed on a hash of the method name and its
+// encoded params. Implementations must be safe for concurrent use.
+type ResponseCache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Put(ctx context.Context, key string, val []byte, ttl time.Duration) error
+}
+
+// cacheKey hashes method+encoded-params into a stable lookup key. The key
+// does not identify the plugin: the same method and params sent to two
+// different plugins yield the same key if both clients share one
+// ResponseCache.
+func cacheKey(method string, encodedParams []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write(encodedParams)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// cacheable reports whether method is safe to serve from, and populate into,
+// a ResponseCache by default. Methods with side effects always bypass the
+// cache regardless of what a caller passes in.
+func cacheable(method string) bool {
+	switch method {
+	case "SessionState.Kill", "SessionState.SetKey", "Publisher.Publish", "Processor.Process":
+		return false
+	default:
+		return true
+	}
+}
+
+// memoryResponseCache is the default ResponseCache: an in-memory store
+// bounded by an LRU eviction policy with per-entry TTLs.
+type memoryResponseCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+type memoryCacheEntry struct {
+	key     string
+	val     []byte
+	expires time.Time
+}
+
+// newMemoryResponseCache returns a ResponseCache that keeps at most capacity
+// entries in memory, evicting the least recently used when full.
+func newMemoryResponseCache(capacity int) *memoryResponseCache {
+	if capacity <= 0 {
+		capacity = defaultCacheCapacity
+	}
+	return &memoryResponseCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (c *memoryResponseCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := el.Value.(*memoryCacheEntry)
+	if time.Now().After(entry.expires) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return nil, false, nil
+	}
+	c.order.MoveToFront(el)
+	return entry.val, true, nil
+}
+
+func (c *memoryResponseCache) Put(ctx context.Context, key string, val []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		entry := el.Value.(*memoryCacheEntry)
+		entry.val = val
+		entry.expires = time.Now().Add(ttl)
+		c.order.MoveToFront(el)
+		return nil
+	}
+	entry := &memoryCacheEntry{key: key, val: val, expires: time.Now().Add(ttl)}
+	c.entries[key] = c.order.PushFront(entry)
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		if oldest != nil {
+			c.order.Remove(oldest)
+			delete(c.entries, oldest.Value.(*memoryCacheEntry).key)
+		}
+	}
+	return nil
+}
+
+// filesystemResponseCache is an opt-in ResponseCache backed by flat files
+// under a directory, for deployments that want a cache to survive a plugin
+// restart without standing up Redis.
+type filesystemResponseCache struct {
+	dir string
+}
+
+// NewFilesystemResponseCache returns a ResponseCache that stores each entry
+// as a file under dir, named after its cache key. dir is created if it does
+// not already exist.
+func NewFilesystemResponseCache(dir string) (ResponseCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &filesystemResponseCache{dir: dir}, nil
+}
+
+// fsCacheEntry is the on-disk representation of a cached response; it needs
+// exported fields so encoding/gob can (de)serialize it.
+type fsCacheEntry struct {
+	Val     []byte
+	Expires time.Time
+}
+
+func (c *filesystemResponseCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	b, err := ioutil.ReadFile(filepath.Join(c.dir, key))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var entry fsCacheEntry
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&entry); err != nil {
+		return nil, false, err
+	}
+	if time.Now().After(entry.Expires) {
+		os.Remove(filepath.Join(c.dir, key))
+		return nil, false, nil
+	}
+	return entry.Val, true, nil
+}
+
+func (c *filesystemResponseCache) Put(ctx context.Context, key string, val []byte, ttl time.Duration) error {
+	entry := fsCacheEntry{Val: val, Expires: time.Now().Add(ttl)}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&entry); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(c.dir, key), buf.Bytes(), 0600)
+}
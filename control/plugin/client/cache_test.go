@@ -0,0 +1,120 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/intelsdi-x/pulse/control/plugin"
+)
+
+func TestMemoryResponseCacheLRUEviction(t *testing.T) {
+	c := newMemoryResponseCache(2)
+	ctx := context.Background()
+
+	c.Put(ctx, "a", []byte("a"), time.Minute)
+	c.Put(ctx, "b", []byte("b"), time.Minute)
+	if _, ok, _ := c.Get(ctx, "a"); !ok {
+		t.Fatal("expected a hit on key \"a\" right after inserting it")
+	}
+	// "a" is now most-recently-used; inserting "c" should evict "b".
+	c.Put(ctx, "c", []byte("c"), time.Minute)
+
+	if _, ok, _ := c.Get(ctx, "b"); ok {
+		t.Fatal("expected key \"b\" to have been evicted as least recently used")
+	}
+	if _, ok, _ := c.Get(ctx, "a"); !ok {
+		t.Fatal("expected key \"a\" to survive eviction")
+	}
+	if _, ok, _ := c.Get(ctx, "c"); !ok {
+		t.Fatal("expected key \"c\" to be present")
+	}
+}
+
+func TestMemoryResponseCacheTTLExpiry(t *testing.T) {
+	c := newMemoryResponseCache(10)
+	ctx := context.Background()
+
+	c.Put(ctx, "k", []byte("v"), 10*time.Millisecond)
+	if _, ok, _ := c.Get(ctx, "k"); !ok {
+		t.Fatal("expected a hit before the TTL elapses")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, ok, _ := c.Get(ctx, "k"); ok {
+		t.Fatal("expected a miss once the TTL has elapsed")
+	}
+}
+
+func TestCacheKeyStable(t *testing.T) {
+	k1 := cacheKey("Collector.CollectMetrics", []byte(`{"a":1}`))
+	k2 := cacheKey("Collector.CollectMetrics", []byte(`{"a":1}`))
+	if k1 != k2 {
+		t.Fatal("cacheKey should be deterministic for identical method+params")
+	}
+	if k1 == cacheKey("Collector.CollectMetrics", []byte(`{"a":2}`)) {
+		t.Fatal("cacheKey should differ for different params")
+	}
+	if k1 == cacheKey("Collector.GetMetricTypes", []byte(`{"a":1}`)) {
+		t.Fatal("cacheKey should differ for different methods")
+	}
+}
+
+func TestCacheable(t *testing.T) {
+	cases := map[string]bool{
+		"SessionState.Kill":            false,
+		"SessionState.SetKey":          false,
+		"Publisher.Publish":            false,
+		"Processor.Process":            false,
+		"Collector.CollectMetrics":     true,
+		"Collector.GetMetricTypes":     true,
+		"SessionState.GetConfigPolicy": true,
+	}
+	for method, want := range cases {
+		if got := cacheable(method); got != want {
+			t.Errorf("cacheable(%q) = %v, want %v", method, got, want)
+		}
+	}
+}
+
+func TestCacheTTLForStaticMethods(t *testing.T) {
+	h := &httpJSONRPCClient{cacheTTL: defaultCacheTTL}
+
+	for _, method := range []string{"Collector.GetMetricTypes", "SessionState.GetConfigPolicy"} {
+		if got := h.cacheTTLFor(method, nil); got != staticCacheTTL {
+			t.Errorf("cacheTTLFor(%q) = %v, want staticCacheTTL (%v)", method, got, staticCacheTTL)
+		}
+	}
+	if got := h.cacheTTLFor("Collector.CollectMetrics", nil); got != defaultCacheTTL {
+		t.Errorf("cacheTTLFor(Collector.CollectMetrics, nil) = %v, want %v", got, defaultCacheTTL)
+	}
+}
+
+func TestCacheTTLForMetricOverride(t *testing.T) {
+	h := &httpJSONRPCClient{
+		cacheTTL: defaultCacheTTL,
+		metricCacheTTLs: map[string]time.Duration{
+			metricNamespaceKey([]string{"intel", "mock", "foo"}): 5 * time.Minute,
+		},
+	}
+
+	overridden := plugin.PluginMetricType{Namespace_: []string{"intel", "mock", "foo"}}
+	if got := h.cacheTTLFor("Collector.CollectMetrics", overridden); got != 5*time.Minute {
+		t.Errorf("cacheTTLFor with override = %v, want 5m", got)
+	}
+
+	unoverridden := plugin.PluginMetricType{Namespace_: []string{"intel", "mock", "bar"}}
+	if got := h.cacheTTLFor("Collector.CollectMetrics", unoverridden); got != defaultCacheTTL {
+		t.Errorf("cacheTTLFor without override = %v, want defaultCacheTTL", got)
+	}
+}
+
+func TestCacheParamBytesNilSentinel(t *testing.T) {
+	h := &httpJSONRPCClient{}
+	b, err := h.cacheParamBytes(nil)
+	if err != nil {
+		t.Fatalf("cacheParamBytes(nil) returned an error: %v", err)
+	}
+	if len(b) == 0 {
+		t.Fatal("cacheParamBytes(nil) should return a stable non-empty sentinel, not fall through to h.encoder")
+	}
+}
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/intelsdi-x/pulse/control/plugin/encoding"
+)
+
+// TestCallBatchDemuxesByID feeds back a batch reply whose objects are
+// shuffled and include one explicit JSON-RPC error, and checks
+// CallBatchWithContext puts each Response back at the index of the Request
+// it answers -- not the order the server happened to write them in.
+func TestCallBatchDemuxesByID(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqs []jsonRPCRequest
+		if err := json.NewDecoder(r.Body).Decode(&reqs); err != nil {
+			t.Fatalf("server: decode batch request: %v", err)
+		}
+		if len(reqs) != 3 {
+			t.Fatalf("server: got %d requests in batch, want 3", len(reqs))
+		}
+
+		// Reply out of order and with one error, to prove demuxing is by id
+		// rather than by position.
+		replies := []jsonRpcResp{
+			{JSONRPC: jsonRPCVersion, Id: reqs[2].Id, Result: json.RawMessage(`"third"`)},
+			{JSONRPC: jsonRPCVersion, Id: reqs[0].Id, Result: json.RawMessage(`"first"`)},
+			{JSONRPC: jsonRPCVersion, Id: reqs[1].Id, Error: &jsonRPCErrorObject{Code: ErrMetricUnavailable, Message: "boom"}},
+		}
+		if err := json.NewEncoder(w).Encode(replies); err != nil {
+			t.Fatalf("server: encode batch reply: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	h := &httpJSONRPCClient{
+		url:     srv.URL,
+		timeout: 5 * time.Second,
+		encoder: encoding.NewJsonEncoder(),
+		breaker: newCircuitBreaker(DefaultClientOptions()),
+	}
+
+	out, err := h.CallBatchWithContext(context.Background(), []Request{
+		{Method: "Collector.CollectMetrics"},
+		{Method: "Collector.CollectMetrics"},
+		{Method: "Collector.CollectMetrics"},
+	})
+	if err != nil {
+		t.Fatalf("CallBatchWithContext() error = %v", err)
+	}
+	if len(out) != 3 {
+		t.Fatalf("len(out) = %d, want 3", len(out))
+	}
+
+	if string(out[0].Result) != `"first"` {
+		t.Errorf("out[0].Result = %s, want \"first\"", out[0].Result)
+	}
+	if out[1].Error == nil {
+		t.Errorf("out[1].Error = nil, want the server's JSON-RPC error")
+	}
+	if string(out[2].Result) != `"third"` {
+		t.Errorf("out[2].Result = %s, want \"third\"", out[2].Result)
+	}
+}
+
+// TestCallBatchUnknownIDsAreIgnored covers the case where doCallBatch sees a
+// reply whose id it never sent: it must be dropped, not panic or clobber an
+// unrelated Response slot.
+func TestCallBatchUnknownIDsAreIgnored(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		replies := []jsonRpcResp{
+			{JSONRPC: jsonRPCVersion, Id: 99999, Result: json.RawMessage(`"stray"`)},
+		}
+		if err := json.NewEncoder(w).Encode(replies); err != nil {
+			t.Fatalf("server: encode batch reply: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	h := &httpJSONRPCClient{
+		url:     srv.URL,
+		timeout: 5 * time.Second,
+		encoder: encoding.NewJsonEncoder(),
+		breaker: newCircuitBreaker(DefaultClientOptions()),
+	}
+
+	out, err := h.CallBatchWithContext(context.Background(), []Request{{Method: "Collector.CollectMetrics"}})
+	if err != nil {
+		t.Fatalf("CallBatchWithContext() error = %v", err)
+	}
+	if len(out) != 1 {
+		t.Fatalf("len(out) = %d, want 1", len(out))
+	}
+	if out[0].Result != nil || out[0].Error != nil {
+		t.Errorf("out[0] = %+v, want a zero-value Response for the unmatched request", out[0])
+	}
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestDeadlineHelpersAreIndependentPerCall is the regression test for the
+// bug WithReadDeadline/WithWriteDeadline replaced: each call derives its own
+// context from whatever the caller passes in, so one goroutine's deadline
+// never leaks into another's, even when both start from the same base ctx.
+func TestDeadlineHelpersAreIndependentPerCall(t *testing.T) {
+	base := context.Background()
+
+	slow, cancelSlow := WithWriteDeadline(base, time.Now().Add(time.Hour))
+	defer cancelSlow()
+	fast, cancelFast := WithReadDeadline(base, time.Now().Add(-time.Millisecond))
+	defer cancelFast()
+
+	select {
+	case <-fast.Done():
+	default:
+		t.Fatal("fast context should already be Done: its deadline is in the past")
+	}
+
+	select {
+	case <-slow.Done():
+		t.Fatal("slow context should not be Done: it shares no state with fast's expired deadline")
+	default:
+	}
+}
+
+func TestWithReadDeadlineHonorsDeadline(t *testing.T) {
+	ctx, cancel := WithReadDeadline(context.Background(), time.Now().Add(20*time.Millisecond))
+	defer cancel()
+
+	select {
+	case <-ctx.Done():
+		t.Fatal("ctx should not be Done yet")
+	default:
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	select {
+	case <-ctx.Done():
+	default:
+		t.Fatal("ctx should be Done once its deadline has elapsed")
+	}
+	if ctx.Err() != context.DeadlineExceeded {
+		t.Errorf("ctx.Err() = %v, want context.DeadlineExceeded", ctx.Err())
+	}
+}
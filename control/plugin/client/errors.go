@@ -0,0 +1,59 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Reserved JSON-RPC error code space for plugin-level errors, mirroring the
+// JSON-RPC 2.0 spec's convention of reserving -32000..-32099 for
+// implementation-defined server errors (the standard range below that,
+// -32700..-32600, is reserved by the spec itself for parse/invalid-request/
+// method-not-found/etc., which this client doesn't special-case).
+const (
+	// ErrPluginPanicked means the plugin process recovered from (or died
+	// from) a panic while servicing the call.
+	ErrPluginPanicked = -32000
+	// ErrConfigInvalid means the config passed with the call failed the
+	// plugin's config policy.
+	ErrConfigInvalid = -32001
+	// ErrMetricUnavailable means the requested metric isn't currently
+	// collectible (e.g. the underlying source is temporarily down).
+	ErrMetricUnavailable = -32002
+	// ErrEncryptionKeyMissing means the plugin expected an encrypted
+	// session but no key (or an invalid one) was set.
+	ErrEncryptionKeyMissing = -32003
+)
+
+// RPCError is a structured JSON-RPC 2.0 error object returned by a
+// plugin's RPC server. Callers can use errors.As to recover a *RPCError and
+// inspect Code rather than string-matching Message, and use Temporary/
+// PluginShouldRestart to decide whether to retry the call, skip it, or kill
+// the plugin outright.
+type RPCError struct {
+	Code    int
+	Message string
+	Data    json.RawMessage
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("jsonrpc error %d: %s", e.Code, e.Message)
+}
+
+// Temporary reports whether the failure is likely to clear on its own, so a
+// caller can choose to retry rather than give up on the metric or plugin.
+func (e *RPCError) Temporary() bool {
+	return e.Code == ErrMetricUnavailable
+}
+
+// PluginShouldRestart reports whether the failure indicates the plugin
+// process itself is in a bad state and should be killed and restarted
+// rather than retried or treated as an ordinary call failure.
+func (e *RPCError) PluginShouldRestart() bool {
+	switch e.Code {
+	case ErrPluginPanicked, ErrEncryptionKeyMissing:
+		return true
+	default:
+		return false
+	}
+}
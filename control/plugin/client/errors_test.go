@@ -0,0 +1,38 @@
+package client
+
+import "testing"
+
+func TestRPCErrorError(t *testing.T) {
+	err := &RPCError{Code: ErrConfigInvalid, Message: "bad config"}
+	if got, want := err.Error(), "jsonrpc error -32001: bad config"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
+
+func TestRPCErrorTemporary(t *testing.T) {
+	cases := map[int]bool{
+		ErrMetricUnavailable:    true,
+		ErrPluginPanicked:       false,
+		ErrConfigInvalid:        false,
+		ErrEncryptionKeyMissing: false,
+	}
+	for code, want := range cases {
+		if got := (&RPCError{Code: code}).Temporary(); got != want {
+			t.Errorf("Temporary() for code %d = %v, want %v", code, got, want)
+		}
+	}
+}
+
+func TestRPCErrorPluginShouldRestart(t *testing.T) {
+	cases := map[int]bool{
+		ErrPluginPanicked:       true,
+		ErrEncryptionKeyMissing: true,
+		ErrConfigInvalid:        false,
+		ErrMetricUnavailable:    false,
+	}
+	for code, want := range cases {
+		if got := (&RPCError{Code: code}).PluginShouldRestart(); got != want {
+			t.Errorf("PluginShouldRestart() for code %d = %v, want %v", code, got, want)
+		}
+	}
+}
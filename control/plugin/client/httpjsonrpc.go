@@ -2,12 +2,14 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"crypto/rsa"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -30,15 +32,64 @@ type httpJSONRPCClient struct {
 	pluginType plugin.PluginType
 	encrypter  *encrypter.Encrypter
 	encoder    encoding.Encoder
+
+	cache           ResponseCache
+	cacheTTL        time.Duration
+	metricCacheTTLs map[string]time.Duration
+
+	breaker *circuitBreaker
+
+	negotiateOnceFn sync.Once
+}
+
+// ClientOpt configures optional behavior on an httpJSONRPCClient at
+// construction time, e.g. WithResponseCache.
+type ClientOpt func(*httpJSONRPCClient)
+
+// WithResponseCache overrides the client's default in-memory ResponseCache
+// with cache, e.g. a filesystem- or Redis-backed implementation.
+func WithResponseCache(cache ResponseCache) ClientOpt {
+	return func(h *httpJSONRPCClient) {
+		h.cache = cache
+	}
+}
+
+// WithCacheTTL overrides the default TTL applied to cacheable responses.
+func WithCacheTTL(ttl time.Duration) ClientOpt {
+	return func(h *httpJSONRPCClient) {
+		h.cacheTTL = ttl
+	}
+}
+
+// WithReadDeadline bounds the single call made with the returned context to
+// t. The deadline covers the whole call, request and reply alike; it is not
+// limited to the response side, and WithWriteDeadline behaves identically.
+// Unlike a deadline set on the client itself, this only affects the call
+// this context is passed to — concurrent callers sharing the same
+// httpJSONRPCClient are unaffected. Equivalent to context.WithDeadline;
+// provided so callers have a read/write-scoped name to reach for, mirroring
+// net.Conn's SetReadDeadline/SetWriteDeadline.
+func WithReadDeadline(ctx context.Context, t time.Time) (context.Context, context.CancelFunc) {
+	return context.WithDeadline(ctx, t)
+}
+
+// WithWriteDeadline bounds the single call made with the returned context to
+// t, request and reply alike; it is not limited to the request side. See
+// WithReadDeadline.
+func WithWriteDeadline(ctx context.Context, t time.Time) (context.Context, context.CancelFunc) {
+	return context.WithDeadline(ctx, t)
 }
 
 // NewCollectorHttpJSONRPCClient returns CollectorHttpJSONRPCClient
-func NewCollectorHttpJSONRPCClient(u string, timeout time.Duration, pub *rsa.PublicKey, secure bool) (PluginCollectorClient, error) {
+func NewCollectorHttpJSONRPCClient(u string, timeout time.Duration, pub *rsa.PublicKey, secure bool, opts ...ClientOpt) (PluginCollectorClient, error) {
 	hjr := &httpJSONRPCClient{
 		url:        u,
 		timeout:    timeout,
 		pluginType: plugin.CollectorPluginType,
 		encoder:    encoding.NewJsonEncoder(),
+		cache:      newMemoryResponseCache(defaultCacheCapacity),
+		cacheTTL:   defaultCacheTTL,
+		breaker:    newCircuitBreaker(DefaultClientOptions()),
 	}
 	if secure {
 		key, err := encrypter.GenerateKey()
@@ -50,15 +101,21 @@ func NewCollectorHttpJSONRPCClient(u string, timeout time.Duration, pub *rsa.Pub
 		hjr.encoder.SetEncrypter(e)
 		hjr.encrypter = e
 	}
+	for _, opt := range opts {
+		opt(hjr)
+	}
 	return hjr, nil
 }
 
-func NewProcessorHttpJSONRPCClient(u string, timeout time.Duration, pub *rsa.PublicKey, secure bool) (PluginProcessorClient, error) {
+func NewProcessorHttpJSONRPCClient(u string, timeout time.Duration, pub *rsa.PublicKey, secure bool, opts ...ClientOpt) (PluginProcessorClient, error) {
 	hjr := &httpJSONRPCClient{
 		url:        u,
 		timeout:    timeout,
 		pluginType: plugin.ProcessorPluginType,
 		encoder:    encoding.NewJsonEncoder(),
+		cache:      newMemoryResponseCache(defaultCacheCapacity),
+		cacheTTL:   defaultCacheTTL,
+		breaker:    newCircuitBreaker(DefaultClientOptions()),
 	}
 	if secure {
 		key, err := encrypter.GenerateKey()
@@ -70,15 +127,21 @@ func NewProcessorHttpJSONRPCClient(u string, timeout time.Duration, pub *rsa.Pub
 		hjr.encoder.SetEncrypter(e)
 		hjr.encrypter = e
 	}
+	for _, opt := range opts {
+		opt(hjr)
+	}
 	return hjr, nil
 }
 
-func NewPublisherHttpJSONRPCClient(u string, timeout time.Duration, pub *rsa.PublicKey, secure bool) (PluginPublisherClient, error) {
+func NewPublisherHttpJSONRPCClient(u string, timeout time.Duration, pub *rsa.PublicKey, secure bool, opts ...ClientOpt) (PluginPublisherClient, error) {
 	hjr := &httpJSONRPCClient{
 		url:        u,
 		timeout:    timeout,
 		pluginType: plugin.PublisherPluginType,
 		encoder:    encoding.NewJsonEncoder(),
+		cache:      newMemoryResponseCache(defaultCacheCapacity),
+		cacheTTL:   defaultCacheTTL,
+		breaker:    newCircuitBreaker(DefaultClientOptions()),
 	}
 	if secure {
 		key, err := encrypter.GenerateKey()
@@ -90,53 +153,92 @@ func NewPublisherHttpJSONRPCClient(u string, timeout time.Duration, pub *rsa.Pub
 		hjr.encoder.SetEncrypter(e)
 		hjr.encrypter = e
 	}
+	for _, opt := range opts {
+		opt(hjr)
+	}
 	return hjr, nil
 }
 
 // Ping
 func (h *httpJSONRPCClient) Ping() error {
-	_, err := h.call("SessionState.Ping", []interface{}{})
+	return h.PingWithContext(context.Background())
+}
+
+// PingWithContext is Ping, but bounded by ctx in addition to the client's
+// configured timeout. Callers can use it to abandon an in-flight ping when
+// the scheduler cancels the task that triggered it.
+func (h *httpJSONRPCClient) PingWithContext(ctx context.Context) error {
+	_, err := h.call(ctx, "SessionState.Ping", []interface{}{})
 	return err
 }
 
 func (h *httpJSONRPCClient) SetKey() error {
+	return h.SetKeyWithContext(context.Background())
+}
+
+// SetKeyWithContext is SetKey, bounded by ctx.
+func (h *httpJSONRPCClient) SetKeyWithContext(ctx context.Context) error {
 	key, err := h.encrypter.EncryptKey()
 	if err != nil {
 		return err
 	}
 	a := plugin.SetKeyArgs{Key: key}
-	_, err = h.call("SessionState.SetKey", []interface{}{a})
+	_, err = h.call(ctx, "SessionState.SetKey", []interface{}{a})
 	return err
 }
 
 // kill
 func (h *httpJSONRPCClient) Kill(reason string) error {
+	return h.KillWithContext(context.Background(), reason)
+}
+
+// KillWithContext is Kill, bounded by ctx.
+func (h *httpJSONRPCClient) KillWithContext(ctx context.Context, reason string) error {
+	// Negotiate before encoding args below: h.encoder may change as a result,
+	// and the plugin expects the negotiated codec, not whatever was current
+	// when this client was constructed.
+	h.negotiateOnce(ctx)
 	args := plugin.KillArgs{Reason: reason}
 	out, err := h.encoder.Encode(args)
 	if err != nil {
 		return err
 	}
 
-	_, err = h.call("SessionState.Kill", []interface{}{out})
+	_, err = h.call(ctx, "SessionState.Kill", []interface{}{out})
 	return err
 }
 
 // CollectMetrics returns collected metrics
 func (h *httpJSONRPCClient) CollectMetrics(mts []core.Metric) ([]core.Metric, error) {
+	return h.CollectMetricsWithContext(context.Background(), mts)
+}
+
+// CollectMetricsWithContext is CollectMetrics, bounded by ctx so the
+// scheduler can cancel an in-flight collect when a task is stopped.
+func (h *httpJSONRPCClient) CollectMetricsWithContext(ctx context.Context, mts []core.Metric) ([]core.Metric, error) {
+	// Negotiate up front: cacheGet/cachePut and the Collector.CollectMetrics
+	// encode below all use h.encoder, so it must reflect the negotiated codec
+	// before any of them run, not just before the call that triggers it.
+	h.negotiateOnce(ctx)
+
 	// Here we create two slices from the requested metric collection. One which
 	// contains the metrics we retreived from the cache, and one from which we had
 	// to use the plugin.
 
-	// This is managed by walking through the complete list and hitting the cache for each item.
+	// This is managed by walking through the complete list and hitting the ResponseCache
+	// for each item, keyed on a hash of the method and that metric's own namespace+config.
 	// If the metric is found in the cache, we nil out that entry in the complete collection.
 	// Then, we walk through the collection once more and create a new slice of metrics which
 	// were not found in the cache.
 	var fromCache []core.Metric
 	for i, m := range mts {
-		var metric core.Metric
-		if metric = metricCache.get(core.JoinNamespace(m.Namespace())); metric != nil {
-			fromCache = append(fromCache, metric)
-			mts[i] = nil
+		pmt := plugin.PluginMetricType{Namespace_: m.Namespace(), Config_: m.Config()}
+		if raw, ok := h.cacheGet(ctx, "Collector.CollectMetrics", pmt); ok {
+			var metric plugin.PluginMetricType
+			if err := h.encoder.Decode(raw, &metric); err == nil {
+				fromCache = append(fromCache, metric)
+				mts[i] = nil
+			}
 		}
 	}
 	var fromPlugin []plugin.PluginMetricType
@@ -155,7 +257,7 @@ func (h *httpJSONRPCClient) CollectMetrics(mts []core.Metric) ([]core.Metric, er
 		if err != nil {
 			return nil, err
 		}
-		res, err := h.call("Collector.CollectMetrics", []interface{}{out})
+		res, err := h.call(ctx, "Collector.CollectMetrics", []interface{}{out})
 		if err != nil {
 			return nil, err
 		}
@@ -173,22 +275,85 @@ func (h *httpJSONRPCClient) CollectMetrics(mts []core.Metric) ([]core.Metric, er
 			return nil, err
 		}
 		for _, m := range mtr.PluginMetrics {
-			metricCache.put(core.JoinNamespace(m.Namespace()), m)
+			pmt := plugin.PluginMetricType{Namespace_: m.Namespace(), Config_: m.Config()}
+			if encoded, err := h.encoder.Encode(m); err == nil {
+				h.cachePut(ctx, "Collector.CollectMetrics", pmt, encoded)
+			}
 			fromCache = append(fromCache, m)
 		}
 	}
 	return fromCache, nil
 }
 
+// cacheParamBytes encodes params to form part of a cache key, with a fixed
+// sentinel for the nil case (GetMetricTypes, GetConfigPolicy) instead of
+// running it through h.encoder: Encode(nil) isn't meaningful for every
+// Encoder implementation, and would otherwise risk making cacheGet/cachePut
+// silently, permanently no-op for every no-arg method if a future codec
+// rejected it.
+func (h *httpJSONRPCClient) cacheParamBytes(params interface{}) ([]byte, error) {
+	if params == nil {
+		return []byte("null"), nil
+	}
+	return h.encoder.Encode(params)
+}
+
+// cacheGet looks up the cached response for method+params, returning false
+// when the method is not cacheable, no cache is configured, or there's no
+// hit. Non-nil params are encoded with the client's current wire codec to
+// form the key, so the same params give a different key once the codec
+// changes (e.g. after negotiation).
+func (h *httpJSONRPCClient) cacheGet(ctx context.Context, method string, params interface{}) ([]byte, bool) {
+	if h.cache == nil || !cacheable(method) {
+		return nil, false
+	}
+	encoded, err := h.cacheParamBytes(params)
+	if err != nil {
+		return nil, false
+	}
+	val, ok, err := h.cache.Get(ctx, cacheKey(method, encoded))
+	if err != nil || !ok {
+		return nil, false
+	}
+	return val, true
+}
+
+// cachePut stores val under the key derived from method+params, honoring
+// the same cacheability policy as cacheGet and the per-method TTL from
+// cacheTTLFor. Errors are swallowed: a cache write failure should never
+// fail the underlying RPC call.
+func (h *httpJSONRPCClient) cachePut(ctx context.Context, method string, params interface{}, val []byte) {
+	if h.cache == nil || !cacheable(method) {
+		return
+	}
+	encoded, err := h.cacheParamBytes(params)
+	if err != nil {
+		return
+	}
+	h.cache.Put(ctx, cacheKey(method, encoded), val, h.cacheTTLFor(method, params))
+}
+
 // GetMetricTypes returns metric types that can be collected
 func (h *httpJSONRPCClient) GetMetricTypes() ([]core.Metric, error) {
-	res, err := h.call("Collector.GetMetricTypes", []interface{}{})
-	if err != nil {
-		return nil, err
+	return h.GetMetricTypesWithContext(context.Background())
+}
+
+// GetMetricTypesWithContext is GetMetricTypes, bounded by ctx. Because
+// GetMetricTypes has no arguments and no side effects, it is a prime
+// candidate for short-circuiting via the client's ResponseCache.
+func (h *httpJSONRPCClient) GetMetricTypesWithContext(ctx context.Context) ([]core.Metric, error) {
+	const method = "Collector.GetMetricTypes"
+	result, cached := h.cacheGet(ctx, method, nil)
+	if !cached {
+		res, err := h.call(ctx, method, []interface{}{})
+		if err != nil {
+			return nil, err
+		}
+		result = res.Result
+		h.cachePut(ctx, method, nil, result)
 	}
 	var mtr plugin.GetMetricTypesReply
-	err = h.encoder.Decode(res.Result, &mtr)
-	if err != nil {
+	if err := h.encoder.Decode(result, &mtr); err != nil {
 		return nil, err
 	}
 	metrics := make([]core.Metric, len(mtr.PluginMetricTypes))
@@ -200,33 +365,54 @@ func (h *httpJSONRPCClient) GetMetricTypes() ([]core.Metric, error) {
 
 // GetConfigPolicy returns a config policy
 func (h *httpJSONRPCClient) GetConfigPolicy() (*cpolicy.ConfigPolicy, error) {
-	res, err := h.call("SessionState.GetConfigPolicy", []interface{}{})
-	if err != nil {
-		logger.WithFields(log.Fields{
-			"_block": "GetConfigPolicy",
-			"result": fmt.Sprintf("%+v", res),
-			"error":  err,
-		}).Error("error getting config policy")
-		return nil, err
-	}
-	if len(res.Result) == 0 {
-		return nil, errors.New(res.Error)
+	return h.GetConfigPolicyWithContext(context.Background())
+}
+
+// GetConfigPolicyWithContext is GetConfigPolicy, bounded by ctx. The config
+// policy is immutable for the lifetime of a plugin, so it is cached like
+// GetMetricTypes.
+func (h *httpJSONRPCClient) GetConfigPolicyWithContext(ctx context.Context) (*cpolicy.ConfigPolicy, error) {
+	const method = "SessionState.GetConfigPolicy"
+	result, cached := h.cacheGet(ctx, method, nil)
+	if !cached {
+		res, err := h.call(ctx, method, []interface{}{})
+		if err != nil {
+			logger.WithFields(log.Fields{
+				"_block": "GetConfigPolicy",
+				"result": fmt.Sprintf("%+v", res),
+				"error":  err,
+			}).Error("error getting config policy")
+			return nil, err
+		}
+		if len(res.Result) == 0 {
+			return nil, errors.New("empty result from SessionState.GetConfigPolicy")
+		}
+		result = res.Result
+		h.cachePut(ctx, method, nil, result)
 	}
 	var cpr plugin.GetConfigPolicyReply
-	err = h.encoder.Decode(res.Result, &cpr)
-	if err != nil {
+	if err := h.encoder.Decode(result, &cpr); err != nil {
 		return nil, err
 	}
 	return cpr.Policy, nil
 }
 
 func (h *httpJSONRPCClient) Publish(contentType string, content []byte, config map[string]ctypes.ConfigValue) error {
+	return h.PublishWithContext(context.Background(), contentType, content, config)
+}
+
+// PublishWithContext is Publish, bounded by ctx so a slow HTTP sink can be
+// bounded without recreating the client.
+func (h *httpJSONRPCClient) PublishWithContext(ctx context.Context, contentType string, content []byte, config map[string]ctypes.ConfigValue) error {
+	// See KillWithContext: negotiate before encoding so h.encoder is settled
+	// before it's used below.
+	h.negotiateOnce(ctx)
 	args := plugin.PublishArgs{ContentType: contentType, Content: content, Config: config}
 	out, err := h.encoder.Encode(args)
 	if err != nil {
 		return nil
 	}
-	_, err = h.call("Publisher.Publish", []interface{}{out})
+	_, err = h.call(ctx, "Publisher.Publish", []interface{}{out})
 	if err != nil {
 		return err
 	}
@@ -234,12 +420,20 @@ func (h *httpJSONRPCClient) Publish(contentType string, content []byte, config m
 }
 
 func (h *httpJSONRPCClient) Process(contentType string, content []byte, config map[string]ctypes.ConfigValue) (string, []byte, error) {
+	return h.ProcessWithContext(context.Background(), contentType, content, config)
+}
+
+// ProcessWithContext is Process, bounded by ctx.
+func (h *httpJSONRPCClient) ProcessWithContext(ctx context.Context, contentType string, content []byte, config map[string]ctypes.ConfigValue) (string, []byte, error) {
+	// See KillWithContext: negotiate before encoding so h.encoder is settled
+	// before it's used below.
+	h.negotiateOnce(ctx)
 	args := plugin.ProcessorArgs{ContentType: contentType, Content: content, Config: config}
 	out, err := h.encoder.Encode(args)
 	if err != nil {
 		return "", nil, err
 	}
-	res, err := h.call("Processor.Process", []interface{}{out})
+	res, err := h.call(ctx, "Processor.Process", []interface{}{out})
 	if err != nil {
 		return "", nil, err
 	}
@@ -254,17 +448,194 @@ func (h *httpJSONRPCClient) GetType() string {
 	return upcaseInitial(h.pluginType.String())
 }
 
+// jsonRPCVersion is the only version of the spec this client speaks.
+const jsonRPCVersion = "2.0"
+
+// jsonRPCRequest is a spec-compliant JSON-RPC 2.0 request object.
+type jsonRPCRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	Id      uint64        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+// jsonRPCErrorObject is the spec-compliant JSON-RPC 2.0 error object. It is
+// wrapped as *RPCError once decoded so callers get a typed, inspectable
+// error rather than a bare string.
+type jsonRPCErrorObject struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// rpcErrorFrom converts a wire-level JSON-RPC 2.0 error object into a
+// *RPCError, or nil if e is nil.
+func rpcErrorFrom(e *jsonRPCErrorObject) error {
+	if e == nil {
+		return nil
+	}
+	return &RPCError{Code: e.Code, Message: e.Message, Data: e.Data}
+}
+
 type jsonRpcResp struct {
-	Id     int    `json:"id"`
-	Result []byte `json:"result"`
-	Error  string `json:"error"`
+	JSONRPC string              `json:"jsonrpc"`
+	Id      uint64              `json:"id"`
+	Result  json.RawMessage     `json:"result,omitempty"`
+	Error   *jsonRPCErrorObject `json:"error,omitempty"`
+}
+
+// Request is one call in a CallBatch invocation.
+type Request struct {
+	Method string
+	Params []interface{}
+}
+
+// Response is the demuxed result of one call in a CallBatch invocation,
+// matched back to its Request by position.
+type Response struct {
+	Result json.RawMessage
+	Error  error
+}
+
+// CallBatch sends methods as a single JSON-RPC 2.0 batch request (a JSON
+// array) so a scheduler can coalesce many CollectMetrics fan-outs from one
+// task into a single HTTP round-trip, then demuxes the replies by id back
+// into the order methods were given.
+func (h *httpJSONRPCClient) CallBatch(methods []Request) ([]Response, error) {
+	return h.CallBatchWithContext(context.Background(), methods)
 }
 
-func (h *httpJSONRPCClient) call(method string, args []interface{}) (*jsonRpcResp, error) {
-	data, err := json.Marshal(map[string]interface{}{
-		"method": method,
-		"id":     h.id,
-		"params": args,
+// CallBatchWithContext is CallBatch, bounded by ctx. Like call, it goes
+// through the circuit breaker and retry layer; a batch is treated as
+// idempotent only if every method in it is.
+func (h *httpJSONRPCClient) CallBatchWithContext(ctx context.Context, methods []Request) ([]Response, error) {
+	if len(methods) == 0 {
+		return nil, nil
+	}
+	idempotent := true
+	for _, m := range methods {
+		if !idempotentMethod(m.Method) {
+			idempotent = false
+			break
+		}
+	}
+
+	var out []Response
+	err := h.withRetry(ctx, idempotent, func() error {
+		var attemptErr error
+		out, attemptErr = h.doCallBatch(ctx, methods)
+		return attemptErr
+	})
+	return out, err
+}
+
+// doCallBatch makes a single, unretried batch round-trip.
+func (h *httpJSONRPCClient) doCallBatch(ctx context.Context, methods []Request) ([]Response, error) {
+	reqs := make([]jsonRPCRequest, len(methods))
+	idToIndex := make(map[uint64]int, len(methods))
+	for i, m := range methods {
+		id := atomic.AddUint64(&h.id, 1)
+		reqs[i] = jsonRPCRequest{JSONRPC: jsonRPCVersion, Id: id, Method: m.Method, Params: m.Params}
+		idToIndex[id] = i
+	}
+
+	data, err := json.Marshal(reqs)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, cancel, err := h.newHTTPRequest(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var raw []jsonRpcResp
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	out := make([]Response, len(methods))
+	for _, r := range raw {
+		i, ok := idToIndex[r.Id]
+		if !ok {
+			continue
+		}
+		out[i] = Response{Result: r.Result, Error: rpcErrorFrom(r.Error)}
+	}
+	return out, nil
+}
+
+// newHTTPRequest builds the outgoing HTTP request for data, deriving ctx
+// from the caller's context and the client's configured timeout, whichever
+// is shortest-lived. Per-call read/write deadlines are the caller's
+// responsibility via WithReadDeadline/WithWriteDeadline on the ctx passed
+// into the *WithContext methods — they are not client-wide state, so they
+// never affect other calls sharing this client. The returned cancel must
+// be called once the request has completed.
+func (h *httpJSONRPCClient) newHTTPRequest(ctx context.Context, data []byte) (*http.Request, context.CancelFunc, error) {
+	cancel := func() {}
+	if h.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", h.url, bytes.NewReader(data))
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	// The outer envelope (method/id/params) stays JSON per the JSON-RPC 2.0
+	// spec; Content-Type/Accept instead describe the wire format used to
+	// encode the nested params/result payloads (h.encoder), so the plugin
+	// knows which codec to use decoding/encoding them.
+	contentType := h.encoder.ContentType()
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Accept", contentType)
+	return req, cancel, nil
+}
+
+// call invokes method, transparently retrying transient failures with
+// exponential backoff and jitter (bounded by the client's ClientOptions)
+// and bailing out early with ErrCircuitOpen if the endpoint's circuit
+// breaker has tripped. Calls with side effects (e.g. Kill, Publish) are
+// only retried if the client opted in via ClientOptions.AllowNonIdempotentRetry.
+func (h *httpJSONRPCClient) call(ctx context.Context, method string, args []interface{}) (*jsonRpcResp, error) {
+	var result *jsonRpcResp
+	err := h.withRetry(ctx, idempotentMethod(method), func() error {
+		var attemptErr error
+		result, attemptErr = h.doCall(ctx, method, args)
+		return attemptErr
+	})
+	return result, err
+}
+
+// idempotentMethod reports whether method is safe to retry automatically;
+// methods with side effects require the caller to opt in via
+// ClientOptions.AllowNonIdempotentRetry.
+func idempotentMethod(method string) bool {
+	switch method {
+	case "SessionState.Kill", "SessionState.SetKey", "Publisher.Publish", "Processor.Process":
+		return false
+	default:
+		return true
+	}
+}
+
+// doCall makes a single, unretried JSON-RPC request/response round-trip.
+func (h *httpJSONRPCClient) doCall(ctx context.Context, method string, args []interface{}) (*jsonRpcResp, error) {
+	if method != "SessionState.Negotiate" {
+		h.negotiateOnce(ctx)
+	}
+	id := atomic.AddUint64(&h.id, 1)
+	data, err := json.Marshal(jsonRPCRequest{
+		JSONRPC: jsonRPCVersion,
+		Method:  method,
+		Id:      id,
+		Params:  args,
 	})
 	if err != nil {
 		logger.WithFields(log.Fields{
@@ -272,13 +643,19 @@ func (h *httpJSONRPCClient) call(method string, args []interface{}) (*jsonRpcRes
 			"url":    h.url,
 			"args":   fmt.Sprintf("%+v", args),
 			"method": method,
-			"id":     h.id,
+			"id":     id,
 			"error":  err,
 		}).Error("error encoding request to json")
 		return nil, err
 	}
-	client := http.Client{Timeout: h.timeout}
-	resp, err := client.Post(h.url, "application/json", bytes.NewReader(data))
+
+	req, cancel, err := h.newHTTPRequest(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		logger.WithFields(log.Fields{
 			"_block":  "call",
@@ -302,6 +679,8 @@ func (h *httpJSONRPCClient) call(method string, args []interface{}) (*jsonRpcRes
 		}).Error("error decoding result")
 		return nil, err
 	}
-	atomic.AddUint64(&h.id, 1)
+	if result.Error != nil {
+		return result, rpcErrorFrom(result.Error)
+	}
 	return result, nil
 }
@@ -0,0 +1,57 @@
+package client
+
+import (
+	"context"
+
+	"github.com/intelsdi-x/pulse/control/plugin/encoding"
+)
+
+// negotiateArgs is what the client sends the plugin to open codec
+// negotiation: its accepted Content-Types, most preferred first.
+type negotiateArgs struct {
+	AcceptContentTypes []string `json:"accept_content_types"`
+}
+
+// negotiateReply is the plugin's response: the Content-Type it picked from
+// the client's list.
+type negotiateReply struct {
+	ContentType string `json:"content_type"`
+}
+
+// negotiateOnce runs SessionState.Negotiate the first time it's called on a
+// given client, then caches the chosen encoder for the rest of its
+// lifetime; every later call is a no-op. Callers that pre-encode their own
+// args with h.encoder (Kill, CollectMetrics, Publish, Process) must call
+// this before that encode, not rely on doCall's negotiateOnce, since by
+// then the args are already bytes in the wrong codec.
+func (h *httpJSONRPCClient) negotiateOnce(ctx context.Context) {
+	h.negotiateOnceFn.Do(func() {
+		h.negotiate(ctx)
+	})
+}
+
+// negotiate asks the plugin to pick a codec from encoding.DefaultNegotiationOrder
+// and, if it picks one this client knows how to speak, switches h.encoder to
+// it (carrying over the existing encrypter, if any). Any failure -- an old
+// plugin that doesn't implement SessionState.Negotiate, or a reply we can't
+// parse -- is silently ignored and the client keeps using its current
+// (JSON, by default) encoder.
+func (h *httpJSONRPCClient) negotiate(ctx context.Context) {
+	args := negotiateArgs{AcceptContentTypes: encoding.DefaultNegotiationOrder}
+	res, err := h.doCall(ctx, "SessionState.Negotiate", []interface{}{args})
+	if err != nil || res == nil || len(res.Result) == 0 {
+		return
+	}
+	var reply negotiateReply
+	if err := h.encoder.Decode(res.Result, &reply); err != nil {
+		return
+	}
+	chosen, ok := encoding.EncoderByContentType(reply.ContentType)
+	if !ok {
+		return
+	}
+	if h.encrypter != nil {
+		chosen.SetEncrypter(h.encrypter)
+	}
+	h.encoder = chosen
+}
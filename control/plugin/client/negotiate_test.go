@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/intelsdi-x/pulse/control/plugin/encoding"
+)
+
+func newTestClient(url string) *httpJSONRPCClient {
+	return &httpJSONRPCClient{
+		url:     url,
+		timeout: 5 * time.Second,
+		encoder: encoding.NewJsonEncoder(),
+		breaker: newCircuitBreaker(DefaultClientOptions()),
+	}
+}
+
+// TestNegotiateSwitchesEncoder covers the happy path: the plugin picks
+// msgpack from our advertised list and h.encoder switches to it.
+func TestNegotiateSwitchesEncoder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req jsonRPCRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		reply := jsonRpcResp{
+			JSONRPC: jsonRPCVersion,
+			Id:      req.Id,
+			Result:  json.RawMessage(`{"content_type":"application/x-msgpack"}`),
+		}
+		json.NewEncoder(w).Encode(reply)
+	}))
+	defer srv.Close()
+
+	h := newTestClient(srv.URL)
+	h.negotiateOnce(context.Background())
+
+	if got := h.encoder.ContentType(); got != "application/x-msgpack" {
+		t.Errorf("h.encoder.ContentType() = %q, want application/x-msgpack", got)
+	}
+}
+
+// TestNegotiateFallsBackOnTransportFailure covers an old plugin that doesn't
+// implement SessionState.Negotiate at all (the endpoint 404s): negotiateOnce
+// must swallow the failure and leave h.encoder as it was, not panic or
+// leave the client in a broken state.
+func TestNegotiateFallsBackOnTransportFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	h := newTestClient(srv.URL)
+	h.negotiateOnce(context.Background())
+
+	if got := h.encoder.ContentType(); got != "application/json" {
+		t.Errorf("h.encoder.ContentType() = %q, want application/json (unchanged)", got)
+	}
+}
+
+// TestNegotiateFallsBackOnUnknownContentType covers a plugin that replies
+// with a Content-Type this client doesn't have an Encoder for: negotiateOnce
+// must keep the current encoder rather than switching to nothing.
+func TestNegotiateFallsBackOnUnknownContentType(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req jsonRPCRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		reply := jsonRpcResp{
+			JSONRPC: jsonRPCVersion,
+			Id:      req.Id,
+			Result:  json.RawMessage(`{"content_type":"application/x-protobuf"}`),
+		}
+		json.NewEncoder(w).Encode(reply)
+	}))
+	defer srv.Close()
+
+	h := newTestClient(srv.URL)
+	h.negotiateOnce(context.Background())
+
+	if got := h.encoder.ContentType(); got != "application/json" {
+		t.Errorf("h.encoder.ContentType() = %q, want application/json (unchanged)", got)
+	}
+}
+
+// TestNegotiateOnceRunsOnce checks the sync.Once guard: a second call to
+// negotiateOnce must not re-hit the plugin.
+func TestNegotiateOnceRunsOnce(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		var req jsonRPCRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		reply := jsonRpcResp{
+			JSONRPC: jsonRPCVersion,
+			Id:      req.Id,
+			Result:  json.RawMessage(`{"content_type":"application/x-msgpack"}`),
+		}
+		json.NewEncoder(w).Encode(reply)
+	}))
+	defer srv.Close()
+
+	h := newTestClient(srv.URL)
+	h.negotiateOnce(context.Background())
+	h.negotiateOnce(context.Background())
+	h.negotiateOnce(context.Background())
+
+	if calls != 1 {
+		t.Errorf("plugin saw %d SessionState.Negotiate calls, want 1", calls)
+	}
+}
@@ -0,0 +1,302 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by call immediately, without attempting the
+// request, when the per-URL circuit breaker has tripped.
+var ErrCircuitOpen = errors.New("client: circuit breaker open, plugin endpoint looks wedged")
+
+// State is a circuit breaker's lifecycle state.
+type State int
+
+const (
+	// StateClosed allows calls through and tracks their outcome.
+	StateClosed State = iota
+	// StateOpen rejects calls outright until the cooldown elapses.
+	StateOpen
+	// StateHalfOpen allows a single probe call through to test recovery.
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// ClientOptions configures the retry/backoff and circuit-breaker behavior
+// wrapped around every httpJSONRPCClient.call. The zero value is not
+// usable; start from DefaultClientOptions.
+type ClientOptions struct {
+	// MaxAttempts is the total number of attempts (including the first),
+	// for transient failures on idempotent calls.
+	MaxAttempts int
+	// BaseDelay and MaxDelay bound the exponential backoff; the actual
+	// delay before the n-th retry (n starting at 1) is chosen uniformly in
+	// [0, min(MaxDelay, BaseDelay*2^(n-1))) (full jitter).
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// AllowNonIdempotentRetry opts a client in to retrying calls with side
+	// effects (Kill, SetKey, Publish, Process). Off by default since a
+	// retried Publish can duplicate data downstream.
+	AllowNonIdempotentRetry bool
+
+	// WindowSize is how far back the circuit breaker looks when computing
+	// a failure rate.
+	WindowSize time.Duration
+	// FailureThreshold is the fraction of calls in WindowSize that must
+	// fail, once MinSamples is met, to trip the breaker.
+	FailureThreshold float64
+	// MinSamples is the minimum number of calls in WindowSize before the
+	// failure rate is considered meaningful.
+	MinSamples int
+	// CooldownPeriod is how long the breaker stays open before allowing a
+	// half-open probe call.
+	CooldownPeriod time.Duration
+
+	// OnRetry, if set, is called before each retry attempt (attempt is
+	// 1-indexed, counting the retry about to happen).
+	OnRetry func(attempt int, err error)
+	// OnStateChange, if set, is called whenever the breaker transitions.
+	OnStateChange func(from, to State)
+}
+
+// DefaultClientOptions returns the retry/breaker configuration used by
+// New*HttpJSONRPCClient when no ClientOptions are supplied: base 50ms,
+// cap 5s, up to 5 attempts, tripping once at least half of at least 10
+// calls in a 1-minute window fail, with a 10s cooldown before probing again.
+func DefaultClientOptions() ClientOptions {
+	return ClientOptions{
+		MaxAttempts:      5,
+		BaseDelay:        50 * time.Millisecond,
+		MaxDelay:         5 * time.Second,
+		WindowSize:       1 * time.Minute,
+		FailureThreshold: 0.5,
+		MinSamples:       10,
+		CooldownPeriod:   10 * time.Second,
+	}
+}
+
+// WithClientOptions overrides the client's default retry/backoff and
+// circuit-breaker configuration.
+func WithClientOptions(o ClientOptions) ClientOpt {
+	return func(h *httpJSONRPCClient) {
+		h.breaker = newCircuitBreaker(o)
+	}
+}
+
+// callResult is one entry in the circuit breaker's sliding window.
+type callResult struct {
+	at      time.Time
+	success bool
+}
+
+// circuitBreaker tracks per-endpoint call outcomes in a sliding window and
+// trips to StateOpen when the failure rate within that window crosses
+// FailureThreshold, backing off callers with ErrCircuitOpen until a
+// half-open probe succeeds.
+type circuitBreaker struct {
+	opts ClientOptions
+
+	mu       sync.Mutex
+	state    State
+	results  []callResult
+	openedAt time.Time
+}
+
+func newCircuitBreaker(opts ClientOptions) *circuitBreaker {
+	return &circuitBreaker{opts: opts, state: StateClosed}
+}
+
+// allow reports whether a call should proceed, transitioning StateOpen to
+// StateHalfOpen once the cooldown has elapsed. The call that makes that
+// transition is the probe; further calls are rejected until its outcome is
+// recorded.
+func (cb *circuitBreaker) allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	switch cb.state {
+	case StateOpen:
+		if time.Since(cb.openedAt) < cb.opts.CooldownPeriod {
+			return false
+		}
+		cb.transition(StateHalfOpen)
+		return true
+	case StateHalfOpen:
+		return false
+	default:
+		return true
+	}
+}
+
+// record stores the outcome of a call and re-evaluates the breaker state.
+func (cb *circuitBreaker) record(success bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := time.Now()
+	cb.results = append(cb.results, callResult{at: now, success: success})
+	cutoff := now.Add(-cb.opts.WindowSize)
+	i := 0
+	for ; i < len(cb.results); i++ {
+		if cb.results[i].at.After(cutoff) {
+			break
+		}
+	}
+	cb.results = cb.results[i:]
+
+	if cb.state == StateHalfOpen {
+		if success {
+			cb.transition(StateClosed)
+			cb.results = nil
+		} else {
+			cb.openedAt = now
+			cb.transition(StateOpen)
+		}
+		return
+	}
+
+	if len(cb.results) < cb.opts.MinSamples {
+		return
+	}
+	failures := 0
+	for _, r := range cb.results {
+		if !r.success {
+			failures++
+		}
+	}
+	if float64(failures)/float64(len(cb.results)) >= cb.opts.FailureThreshold {
+		cb.openedAt = now
+		cb.transition(StateOpen)
+	}
+}
+
+// transition must be called with cb.mu held.
+func (cb *circuitBreaker) transition(to State) {
+	if cb.state == to {
+		return
+	}
+	from := cb.state
+	cb.state = to
+	if cb.opts.OnStateChange != nil {
+		cb.opts.OnStateChange(from, to)
+	}
+}
+
+// Stats returns counts suitable for Prometheus-style scraping: the current
+// breaker state plus successes/failures observed within WindowSize.
+func (cb *circuitBreaker) Stats() BreakerStats {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	stats := BreakerStats{State: cb.state}
+	cutoff := time.Now().Add(-cb.opts.WindowSize)
+	for _, r := range cb.results {
+		if !r.at.After(cutoff) {
+			continue
+		}
+		if r.success {
+			stats.Successes++
+		} else {
+			stats.Failures++
+		}
+	}
+	return stats
+}
+
+// BreakerStats is a point-in-time snapshot of a circuit breaker, returned
+// by httpJSONRPCClient.Stats.
+type BreakerStats struct {
+	State     State
+	Successes int
+	Failures  int
+}
+
+// Stats returns the current circuit breaker statistics for this client's
+// plugin endpoint.
+func (h *httpJSONRPCClient) Stats() BreakerStats {
+	return h.breaker.Stats()
+}
+
+// isTransientErr reports whether err looks like a retryable, transient
+// failure: any error that is not an *RPCError (network errors, decode
+// errors caused by an empty/malformed body, and every other cause alike),
+// or an *RPCError the plugin itself flagged as Temporary (e.g.
+// ErrMetricUnavailable). Any other *RPCError means the plugin explicitly
+// rejected the call, which retrying won't fix.
+func isTransientErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		return rpcErr.Temporary()
+	}
+	return true
+}
+
+// backoff returns the full-jitter exponential delay before attempt (1-indexed).
+func backoff(attempt int, base, max time.Duration) time.Duration {
+	d := base << uint(attempt-1)
+	if d <= 0 || d > max {
+		d = max
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(d)))
+	if err != nil {
+		return d / 2
+	}
+	return time.Duration(n.Int64())
+}
+
+// withRetry runs attempt repeatedly, respecting the circuit breaker and the
+// configured backoff/jitter, until it succeeds, ctx is done, attempts are
+// exhausted, or the error isn't transient. idempotent gates whether a
+// non-2xx-ish transient failure is allowed to retry at all.
+func (h *httpJSONRPCClient) withRetry(ctx context.Context, idempotent bool, attempt func() error) error {
+	if !h.breaker.allow() {
+		return ErrCircuitOpen
+	}
+
+	maxAttempts := h.breaker.opts.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	canRetry := idempotent || h.breaker.opts.AllowNonIdempotentRetry
+
+	var err error
+	for i := 1; i <= maxAttempts; i++ {
+		err = attempt()
+		if err == nil {
+			h.breaker.record(true)
+			return nil
+		}
+		if !canRetry || !isTransientErr(err) || i == maxAttempts {
+			break
+		}
+		if h.breaker.opts.OnRetry != nil {
+			h.breaker.opts.OnRetry(i, err)
+		}
+		delay := backoff(i, h.breaker.opts.BaseDelay, h.breaker.opts.MaxDelay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			h.breaker.record(false)
+			return ctx.Err()
+		}
+	}
+	h.breaker.record(false)
+	return err
+}
@@ -0,0 +1,162 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBackoffBounds(t *testing.T) {
+	base := 10 * time.Millisecond
+	max := 1 * time.Second
+	for attempt := 1; attempt <= 20; attempt++ {
+		for i := 0; i < 50; i++ {
+			d := backoff(attempt, base, max)
+			if d < 0 || d > max {
+				t.Fatalf("attempt %d: backoff %v out of bounds [0, %v]", attempt, d, max)
+			}
+		}
+	}
+}
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	var transitions []State
+	opts := ClientOptions{
+		WindowSize:       time.Minute,
+		FailureThreshold: 0.5,
+		MinSamples:       4,
+		CooldownPeriod:   10 * time.Millisecond,
+		OnStateChange: func(from, to State) {
+			transitions = append(transitions, to)
+		},
+	}
+	cb := newCircuitBreaker(opts)
+
+	if !cb.allow() {
+		t.Fatal("closed breaker should allow calls")
+	}
+
+	cb.record(false)
+	cb.record(false)
+	cb.record(false)
+	cb.record(false)
+	if cb.Stats().State != StateOpen {
+		t.Fatalf("expected StateOpen after breaching FailureThreshold, got %v", cb.Stats().State)
+	}
+
+	if cb.allow() {
+		t.Fatal("open breaker should reject calls before cooldown elapses")
+	}
+
+	time.Sleep(opts.CooldownPeriod * 2)
+	if !cb.allow() {
+		t.Fatal("open breaker should allow a probe call once cooldown elapses")
+	}
+	if cb.Stats().State != StateHalfOpen {
+		t.Fatalf("expected StateHalfOpen after cooldown, got %v", cb.Stats().State)
+	}
+
+	cb.record(false)
+	if cb.Stats().State != StateOpen {
+		t.Fatalf("expected a failed half-open probe to reopen the breaker, got %v", cb.Stats().State)
+	}
+
+	time.Sleep(opts.CooldownPeriod * 2)
+	cb.allow()
+	cb.record(true)
+	if cb.Stats().State != StateClosed {
+		t.Fatalf("expected a successful half-open probe to close the breaker, got %v", cb.Stats().State)
+	}
+
+	want := []State{StateOpen, StateHalfOpen, StateOpen, StateHalfOpen, StateClosed}
+	if len(transitions) != len(want) {
+		t.Fatalf("transitions = %v, want %v", transitions, want)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Fatalf("transitions = %v, want %v", transitions, want)
+		}
+	}
+}
+
+func TestIsTransientErr(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"network error", errors.New("connection refused"), true},
+		{"temporary rpc error", &RPCError{Code: ErrMetricUnavailable}, true},
+		{"explicit rejection", &RPCError{Code: ErrConfigInvalid}, false},
+	}
+	for _, c := range cases {
+		if got := isTransientErr(c.err); got != c.want {
+			t.Errorf("%s: isTransientErr() = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestWithRetrySucceedsAfterTransientFailures(t *testing.T) {
+	opts := DefaultClientOptions()
+	opts.BaseDelay = time.Millisecond
+	opts.MaxDelay = 5 * time.Millisecond
+	opts.MaxAttempts = 3
+	h := &httpJSONRPCClient{breaker: newCircuitBreaker(opts)}
+
+	attempts := 0
+	err := h.withRetry(context.Background(), true, func() error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("transient")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("withRetry() = %v, want nil", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("attempts = %d, want 3", attempts)
+	}
+}
+
+func TestWithRetryDoesNotRetryNonIdempotent(t *testing.T) {
+	opts := DefaultClientOptions()
+	opts.BaseDelay = time.Millisecond
+	opts.MaxDelay = 5 * time.Millisecond
+	h := &httpJSONRPCClient{breaker: newCircuitBreaker(opts)}
+
+	attempts := 0
+	err := h.withRetry(context.Background(), false, func() error {
+		attempts++
+		return errors.New("transient")
+	})
+	if err == nil {
+		t.Fatal("withRetry() = nil, want the underlying error")
+	}
+	if attempts != 1 {
+		t.Fatalf("attempts = %d, want 1 (non-idempotent calls must not be retried)", attempts)
+	}
+}
+
+func TestWithRetryCircuitOpenShortCircuits(t *testing.T) {
+	opts := DefaultClientOptions()
+	opts.MinSamples = 1
+	opts.FailureThreshold = 0
+	opts.CooldownPeriod = time.Hour
+	h := &httpJSONRPCClient{breaker: newCircuitBreaker(opts)}
+	h.breaker.record(false)
+
+	attempts := 0
+	err := h.withRetry(context.Background(), true, func() error {
+		attempts++
+		return nil
+	})
+	if err != ErrCircuitOpen {
+		t.Fatalf("withRetry() = %v, want ErrCircuitOpen", err)
+	}
+	if attempts != 0 {
+		t.Fatalf("attempts = %d, want 0 (breaker should reject before calling attempt)", attempts)
+	}
+}
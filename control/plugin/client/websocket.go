@@ -0,0 +1,184 @@
+package client
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	log "github.com/Sirupsen/logrus"
+
+	"github.com/intelsdi-x/pulse/control/plugin"
+	"github.com/intelsdi-x/pulse/core"
+)
+
+// metricUpdateNotification is the server-initiated JSON-RPC 2.0 notification
+// (no id) a subscription-capable collector plugin pushes for each new
+// sample, instead of waiting to be polled via Collector.CollectMetrics.
+type metricUpdateNotification struct {
+	JSONRPC string             `json:"jsonrpc"`
+	Method  string             `json:"method"`
+	Params  metricUpdateParams `json:"params"`
+}
+
+type metricUpdateParams struct {
+	Metrics []plugin.PluginMetricType `json:"metrics"`
+}
+
+// collectorWebSocketClient is a PluginCollectorClient that, in addition to
+// the usual request/response calls inherited from httpJSONRPCClient, can
+// subscribe to server-pushed "metricUpdate" notifications over a WebSocket
+// connection rather than being polled.
+type collectorWebSocketClient struct {
+	*httpJSONRPCClient
+
+	wsURL string
+
+	// subscriptionsSupported is the capability flag read from the plugin's
+	// meta at load time, before this client ever existed. It's supplied by
+	// the caller -- not probed by dialing -- so the scheduler never pays for
+	// a failed WebSocket handshake against a plugin that never advertised
+	// subscription support.
+	subscriptionsSupported bool
+
+	// subscribed guards against calling Subscribe more than once on the
+	// same client: gorilla's websocket.Conn allows only one concurrent
+	// reader and one concurrent writer, so a second Subscribe would start
+	// a second ReadJSON/WriteJSON pair racing the first.
+	subscribed int32
+
+	connMu sync.Mutex
+	conn   *websocket.Conn
+}
+
+// NewCollectorWebSocketClient returns a PluginCollectorClient that talks to
+// a collector plugin over WebSocket. supportsSubscriptions is the
+// subscription capability flag from the plugin's meta, as read by the
+// caller when it loaded the plugin; SupportsSubscriptions reports it back
+// verbatim. Use Subscribe to receive server-pushed samples; the embedded
+// httpJSONRPCClient methods (CollectMetrics, Ping, etc.) remain available
+// unchanged for plugins, or calls, that don't use the subscription mode.
+func NewCollectorWebSocketClient(u string, timeout time.Duration, pub *rsa.PublicKey, secure bool, supportsSubscriptions bool, opts ...ClientOpt) (PluginCollectorClient, error) {
+	httpClient, err := NewCollectorHttpJSONRPCClient(u, timeout, pub, secure, opts...)
+	if err != nil {
+		return nil, err
+	}
+	wsURL := "ws" + strings.TrimPrefix(u, "http")
+	return &collectorWebSocketClient{
+		httpJSONRPCClient:      httpClient.(*httpJSONRPCClient),
+		wsURL:                  wsURL,
+		subscriptionsSupported: supportsSubscriptions,
+	}, nil
+}
+
+// SupportsSubscriptions reports the subscription capability flag this
+// client was constructed with, read from the plugin's meta by the caller.
+// The scheduler should fall back to polling via
+// CollectMetrics/CollectMetricsWithContext when this is false, and should
+// never need to call Subscribe (and dial) to find that out.
+func (c *collectorWebSocketClient) SupportsSubscriptions(ctx context.Context) bool {
+	return c.subscriptionsSupported
+}
+
+func (c *collectorWebSocketClient) dial(ctx context.Context) (*websocket.Conn, error) {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	if c.conn != nil {
+		return c.conn, nil
+	}
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, c.wsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.conn = conn
+	return conn, nil
+}
+
+// Subscribe opens (or reuses) the plugin's WebSocket connection and returns
+// a channel of metrics the plugin pushes as "metricUpdate" notifications.
+// The channel is closed when ctx is canceled or the connection is lost; the
+// caller should fall back to polling in either case. Subscribe may only be
+// called once per client instance -- a second call returns an error rather
+// than starting a second reader/writer pair on the same connection.
+func (c *collectorWebSocketClient) Subscribe(ctx context.Context, mts []core.Metric) (<-chan core.Metric, error) {
+	if !atomic.CompareAndSwapInt32(&c.subscribed, 0, 1) {
+		return nil, errors.New("client: Subscribe already called on this client")
+	}
+
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	args := &plugin.CollectMetricsArgs{}
+	for _, m := range mts {
+		args.PluginMetricTypes = append(args.PluginMetricTypes, plugin.PluginMetricType{
+			Namespace_: m.Namespace(),
+			Config_:    m.Config(),
+		})
+	}
+	sub := jsonRPCRequest{
+		JSONRPC: jsonRPCVersion,
+		Id:      atomic.AddUint64(&c.id, 1),
+		Method:  "Collector.Subscribe",
+		Params:  []interface{}{args},
+	}
+	if err := conn.WriteJSON(sub); err != nil {
+		return nil, err
+	}
+
+	// conn.ReadJSON below blocks indefinitely if the plugin goes quiet; it
+	// only notices ctx has been canceled once a message actually arrives.
+	// This watcher closes the connection as soon as ctx is done so the
+	// blocked ReadJSON unblocks with an error and the read loop can return.
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	out := make(chan core.Metric)
+	go func() {
+		defer close(out)
+		defer close(done)
+		for {
+			var notif metricUpdateNotification
+			if err := conn.ReadJSON(&notif); err != nil {
+				logger.WithFields(log.Fields{
+					"_block": "Subscribe",
+					"url":    c.wsURL,
+					"error":  err,
+				}).Error("websocket read failed, ending subscription")
+				return
+			}
+			for _, m := range notif.Params.Metrics {
+				select {
+				case out <- m:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// Close tears down the underlying WebSocket connection, if one is open.
+func (c *collectorWebSocketClient) Close() error {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
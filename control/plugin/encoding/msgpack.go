@@ -0,0 +1,52 @@
+package encoding
+
+import (
+	"bytes"
+
+	"github.com/ugorji/go/codec"
+
+	"github.com/intelsdi-x/pulse/control/plugin/encrypter"
+)
+
+var msgpackHandle codec.MsgpackHandle
+
+// msgpackEncoder is a MessagePack-based Encoder, offering a smaller wire
+// size and cheaper decode than JSON for high-cardinality collectors.
+type msgpackEncoder struct {
+	encrypter *encrypter.Encrypter
+}
+
+// NewMsgpackEncoder returns a MessagePack-based Encoder.
+func NewMsgpackEncoder() Encoder {
+	return &msgpackEncoder{}
+}
+
+func (e *msgpackEncoder) ContentType() string {
+	return "application/x-msgpack"
+}
+
+func (e *msgpackEncoder) SetEncrypter(enc *encrypter.Encrypter) {
+	e.encrypter = enc
+}
+
+func (e *msgpackEncoder) Encode(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := codec.NewEncoder(&buf, &msgpackHandle).Encode(v); err != nil {
+		return nil, err
+	}
+	if e.encrypter != nil {
+		return e.encrypter.Encrypt(buf.Bytes())
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *msgpackEncoder) Decode(data []byte, v interface{}) error {
+	if e.encrypter != nil {
+		decrypted, err := e.encrypter.Decrypt(data)
+		if err != nil {
+			return err
+		}
+		data = decrypted
+	}
+	return codec.NewDecoder(bytes.NewReader(data), &msgpackHandle).Decode(v)
+}
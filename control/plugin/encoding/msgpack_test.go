@@ -0,0 +1,48 @@
+package encoding
+
+import (
+	"reflect"
+	"testing"
+)
+
+type msgpackTestPayload struct {
+	Name      string
+	Count     int
+	Namespace []string
+}
+
+func TestMsgpackEncoderRoundTrip(t *testing.T) {
+	e := NewMsgpackEncoder()
+	want := msgpackTestPayload{Name: "cpu", Count: 3, Namespace: []string{"intel", "mock", "cpu"}}
+
+	b, err := e.Encode(want)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	var got msgpackTestPayload
+	if err := e.Decode(b, &got); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("round trip = %+v, want %+v", got, want)
+	}
+}
+
+func TestMsgpackEncoderContentType(t *testing.T) {
+	if ct := NewMsgpackEncoder().ContentType(); ct != "application/x-msgpack" {
+		t.Errorf("ContentType() = %q, want application/x-msgpack", ct)
+	}
+}
+
+func TestEncoderByContentType(t *testing.T) {
+	if _, ok := EncoderByContentType("application/x-msgpack"); !ok {
+		t.Error("EncoderByContentType(application/x-msgpack) = false, want true")
+	}
+	if _, ok := EncoderByContentType("application/json"); !ok {
+		t.Error("EncoderByContentType(application/json) = false, want true")
+	}
+	if _, ok := EncoderByContentType("application/x-protobuf"); ok {
+		t.Error("EncoderByContentType(application/x-protobuf) = true, want false (protobuf isn't registered)")
+	}
+}